@@ -0,0 +1,295 @@
+/*
+Copyright 2022 The Authors of https://github.com/CDK-TEAM/CDK .
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evaluate
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// apparmorSensitiveMountpoints are host paths whose write access from inside
+// a container is a well-known escape primitive (e.g. writing
+// /var/run/docker.sock to talk to the host daemon, or /proc/sys and
+// /sys entries that affect the host kernel).
+var apparmorSensitiveMountpoints = []string{
+	"/proc/",
+	"/sys/",
+	"/var/run/docker.sock",
+	"/run/docker.sock",
+	"/var/run/crio/crio.sock",
+}
+
+// apparmorProfileRules is the result of parsing one AppArmor profile: enough
+// structure to diff it against docker-default.
+type apparmorProfileRules struct {
+	name         string
+	capabilities map[string]bool
+	filesByPath  map[string]string // path -> mode flags for allow rules only, e.g. "rw", "rwl"
+	mount        bool
+	pivotRoot    bool
+	ptraceTrace  bool
+	network      bool
+}
+
+// apparmorCapabilityLine matches "capability <name>," rules.
+var apparmorCapabilityLine = regexp.MustCompile(`^capability\s+([a-z_]+)\s*,`)
+
+// apparmorFileLine matches file rules of the form `<path> <mode>,` with an
+// optional leading access qualifier (owner, audit, deny, ...), captured
+// separately so callers can tell an allow rule from a deny rule. A rule
+// with a trailing "->" exec transition does not match and is skipped. The
+// path's optional surrounding quotes are matched independently rather than
+// with a backreference, since Go's RE2-based regexp engine doesn't support
+// those; they sit outside the capture group, so the captured path never
+// includes them.
+var apparmorFileLine = regexp.MustCompile(`^((?:(?:audit|deny|owner)\s+)*)"?(/\S*?)"?\s+([rwmlkix]+)\s*,`)
+
+// apparmorNetworkLine matches "network [<family>] [<type>],".
+var apparmorNetworkLine = regexp.MustCompile(`^network\b`)
+
+// apparmorPtraceLine matches a "ptrace" rule, optionally followed by a
+// parenthesized, comma-separated mode list, e.g. "ptrace (trace)" or
+// "ptrace (read, tracedby)". A bare "ptrace," with no mode list grants every
+// mode, including trace; an explicit list only grants what it names. Either
+// way the mode list - not the literal substring "ptrace" - is what decides
+// whether trace permission is granted.
+var apparmorPtraceLine = regexp.MustCompile(`^ptrace\b\s*(?:\(([^)]*)\))?`)
+
+// CheckAppArmorProfileRules locates the AppArmor profile attached to the
+// current process, parses it, and diffs the result against the bundled
+// docker-default profile so CDK operators can see how the container's
+// confinement differs from the out-of-the-box runtime.
+func CheckAppArmorProfileRules() []Finding {
+	label, err := os.ReadFile("/proc/self/attr/current")
+	if err != nil {
+		return []Finding{{Severity: SeverityInfo, Title: fmt.Sprintf("unable to read /proc/self/attr/current: %v", err)}}
+	}
+	name := strings.TrimRight(strings.TrimSpace(string(label)), "\x00")
+	if name == "" || name == "unconfined" {
+		return []Finding{{Severity: SeverityMedium, Title: "container is unconfined, no AppArmor profile to analyze"}}
+	}
+	// The label is "<profile> (enforce)" while loaded policies carry mode
+	// suffixes too; only the profile name is needed to look up its source.
+	name = strings.Fields(name)[0]
+
+	raw, err := readAppArmorProfileSource(name)
+	if err != nil {
+		return []Finding{{Severity: SeverityInfo, Title: fmt.Sprintf("unable to locate policy source for %q: %v", name, err)}}
+	}
+
+	rules := parseAppArmorProfile(name, raw)
+	defaultRules := parseAppArmorProfile("docker-default", dockerDefaultAppArmorProfile)
+
+	var findings []Finding
+	findings = append(findings, appArmorCapabilityDiffFindings(rules, defaultRules)...)
+	findings = append(findings, appArmorSensitivePathFindings(rules)...)
+	findings = append(findings, Finding{
+		Severity: SeverityInfo,
+		Title:    "AppArmor profile escape-relevant rules",
+		Evidence: map[string]string{
+			"profile":        name,
+			"mount":          fmt.Sprintf("%t", rules.mount),
+			"pivot_root":     fmt.Sprintf("%t", rules.pivotRoot),
+			"ptrace (trace)": fmt.Sprintf("%t", rules.ptraceTrace),
+		},
+	})
+	return findings
+}
+
+// readAppArmorProfileSource looks for the profile's text under
+// /etc/apparmor.d/ first (the usual place policies are authored), falling
+// back to the kernel's own copy of the loaded policy under
+// /sys/kernel/security/apparmor/policy/profiles/<name>/raw_data, which is
+// present whenever CONFIG_SECURITY_APPARMOR_EXPORT_BINARY is enabled.
+func readAppArmorProfileSource(name string) ([]byte, error) {
+	candidates := []string{
+		"/etc/apparmor.d/" + name,
+		"/etc/apparmor.d/containers/" + name,
+	}
+	for _, path := range candidates {
+		if data, err := os.ReadFile(path); err == nil {
+			return data, nil
+		}
+	}
+
+	rawPath := "/sys/kernel/security/apparmor/policy/profiles/" + name + "/raw_data"
+	if data, err := os.ReadFile(rawPath); err == nil {
+		return data, nil
+	}
+
+	return nil, fmt.Errorf("no profile source found under /etc/apparmor.d/ or %s", rawPath)
+}
+
+// parseAppArmorProfile implements a minimal parser for the subset of
+// AppArmor profile syntax CDK cares about: capability rules, file rules
+// with their mode flags, mount/pivot_root/ptrace rules, and network rules.
+// Signal rules are not extracted. It is line-oriented and does not evaluate variables, includes, or
+// nested profile blocks, which is sufficient for the stock docker-default
+// and cri-containerd.apparmor.d profiles.
+func parseAppArmorProfile(name string, raw []byte) apparmorProfileRules {
+	rules := apparmorProfileRules{
+		name:         name,
+		capabilities: map[string]bool{},
+		filesByPath:  map[string]string{},
+	}
+	for _, line := range strings.Split(string(raw), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if m := apparmorCapabilityLine.FindStringSubmatch(line); m != nil {
+			rules.capabilities[m[1]] = true
+			continue
+		}
+		if apparmorNetworkLine.MatchString(line) {
+			rules.network = true
+			continue
+		}
+		if strings.HasPrefix(line, "mount") {
+			rules.mount = true
+			continue
+		}
+		if strings.HasPrefix(line, "pivot_root") {
+			rules.pivotRoot = true
+			continue
+		}
+		if m := apparmorPtraceLine.FindStringSubmatch(line); m != nil {
+			modes := m[1]
+			if modes == "" {
+				rules.ptraceTrace = true // bare "ptrace," grants every mode
+			} else {
+				for _, mode := range strings.Split(modes, ",") {
+					if strings.TrimSpace(mode) == "trace" {
+						rules.ptraceTrace = true
+						break
+					}
+				}
+			}
+			continue
+		}
+		if m := apparmorFileLine.FindStringSubmatch(line); m != nil {
+			qualifiers, path, mode := m[1], m[2], m[3]
+			if strings.Contains(qualifiers, "deny") {
+				// A deny rule removes access rather than granting it, so it
+				// must not be treated as a path the profile makes writable.
+				continue
+			}
+			rules.filesByPath[path] = mode
+			continue
+		}
+	}
+	return rules
+}
+
+// appArmorCapabilityDiffFindings reports capabilities the profile under
+// test grants beyond the docker-default baseline.
+func appArmorCapabilityDiffFindings(rules, baseline apparmorProfileRules) []Finding {
+	var extra []string
+	for cap := range rules.capabilities {
+		if !baseline.capabilities[cap] {
+			extra = append(extra, cap)
+		}
+	}
+	sort.Strings(extra)
+	if len(extra) == 0 {
+		return []Finding{{Severity: SeverityInfo, Title: "no extra capabilities granted beyond docker-default"}}
+	}
+	return []Finding{{
+		Severity:    SeverityHigh,
+		Title:       "extra capabilities granted beyond docker-default",
+		Evidence:    map[string]string{"capabilities": strings.Join(extra, ", ")},
+		Remediation: "drop the listed capabilities from the profile or the container's --cap-add list",
+	}}
+}
+
+// appArmorSensitivePathFindings flags writable file rules that overlap host
+// paths commonly abused in container escapes.
+func appArmorSensitivePathFindings(rules apparmorProfileRules) []Finding {
+	var hits []string
+	for path, mode := range rules.filesByPath {
+		if !strings.Contains(mode, "w") {
+			continue
+		}
+		for _, sensitive := range apparmorSensitiveMountpoints {
+			if strings.HasPrefix(path, sensitive) {
+				hits = append(hits, fmt.Sprintf("%s (%s)", path, mode))
+				break
+			}
+		}
+	}
+	sort.Strings(hits)
+	if len(hits) == 0 {
+		return []Finding{{Severity: SeverityInfo, Title: "no writable rules overlap known-sensitive host mountpoints"}}
+	}
+	return []Finding{{
+		Severity:    SeverityHigh,
+		Title:       "writable AppArmor rules overlap sensitive host mountpoints",
+		Evidence:    map[string]string{"paths": strings.Join(hits, ", ")},
+		Remediation: "remove write access to host-sensitive paths from the profile",
+	}}
+}
+
+// dockerDefaultAppArmorProfile is a trimmed copy of the upstream
+// docker-default profile (moby/moby contrib/apparmor/template.go), reduced
+// to the rule kinds parseAppArmorProfile understands. It is only used as a
+// diff baseline, never loaded into the kernel.
+const dockerDefaultAppArmorProfile = `
+profile docker-default flags=(attach_disconnected,mediate_deleted) {
+  capability chown,
+  capability dac_override,
+  capability fowner,
+  capability fsetid,
+  capability kill,
+  capability mknod,
+  capability net_bind_service,
+  capability net_raw,
+  capability setfcap,
+  capability setgid,
+  capability setpcap,
+  capability setuid,
+  capability sys_chroot,
+  capability audit_write,
+
+  network,
+
+  deny @{PROC}/* w,
+  deny /sys/[^f]*/** wklx,
+  deny /sys/f[^s]*/** wklx,
+  deny /sys/fs/[^c]*/** wklx,
+  deny /sys/fs/c[^g]*/** wklx,
+  deny /sys/fs/cg[^r]*/** wklx,
+  deny /sys/kernel/security/** rwklx,
+
+  /etc/** r,
+  /usr/** rix,
+  /lib/** rix,
+  /bin/** rix,
+  /dev/null rw,
+  /dev/urandom r,
+
+  deny mount,
+  deny ptrace (trace) peer=docker-default,
+}
+`
+
+func init() {
+	RegisterSimpleCheck(CategorySecurity, "security.apparmor_profile_diff", "Parse the active AppArmor profile and diff it against docker-default", CheckAppArmorProfileRules)
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2022 The Authors of https://github.com/CDK-TEAM/CDK .
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evaluate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAppArmorProfile_CapabilitiesAndNetwork(t *testing.T) {
+	rules := parseAppArmorProfile("test", []byte(`
+profile test {
+  capability sys_admin,
+  capability chown,
+  network,
+}
+`))
+	assert.True(t, rules.capabilities["sys_admin"])
+	assert.True(t, rules.capabilities["chown"])
+	assert.True(t, rules.network)
+}
+
+func TestParseAppArmorProfile_AllowFileRuleIsRecorded(t *testing.T) {
+	rules := parseAppArmorProfile("test", []byte(`/etc/** r,` + "\n" + `/dev/null rw,`))
+	assert.Equal(t, "r", rules.filesByPath["/etc/**"])
+	assert.Equal(t, "rw", rules.filesByPath["/dev/null"])
+}
+
+func TestParseAppArmorProfile_QuotedPathDoesNotPanic(t *testing.T) {
+	assert.NotPanics(t, func() {
+		rules := parseAppArmorProfile("test", []byte(`"/etc/passwd" r,`))
+		assert.Equal(t, "r", rules.filesByPath["/etc/passwd"])
+	})
+}
+
+func TestParseAppArmorProfile_DenyFileRuleIsNotRecordedAsWritable(t *testing.T) {
+	rules := parseAppArmorProfile("test", []byte(`deny /sys/[^f]*/** wklx,`))
+	_, present := rules.filesByPath["/sys/[^f]*/**"]
+	assert.False(t, present, "a deny rule must not be treated as granting access")
+}
+
+func TestParseAppArmorProfile_PtraceModes(t *testing.T) {
+	cases := []struct {
+		name       string
+		line       string
+		wantsTrace bool
+	}{
+		{"bare ptrace grants every mode", "ptrace,", true},
+		{"explicit trace mode", "ptrace (trace),", true},
+		{"read mode only", "ptrace (read),", false},
+		{"tracedby mode only", "ptrace (tracedby),", false},
+		{"trace among several modes", "ptrace (read, trace),", true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rules := parseAppArmorProfile("test", []byte(c.line))
+			assert.Equal(t, c.wantsTrace, rules.ptraceTrace)
+		})
+	}
+}
+
+func TestAppArmorSensitivePathFindings_DockerDefaultHasNoFalsePositive(t *testing.T) {
+	rules := parseAppArmorProfile("docker-default", []byte(dockerDefaultAppArmorProfile))
+	findings := appArmorSensitivePathFindings(rules)
+	for _, f := range findings {
+		assert.NotEqual(t, SeverityHigh, f.Severity, "docker-default only denies access to /proc and /sys, it should not be flagged as granting it: %+v", f)
+	}
+}
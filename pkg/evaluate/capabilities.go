@@ -0,0 +1,319 @@
+/*
+Copyright 2022 The Authors of https://github.com/CDK-TEAM/CDK .
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evaluate
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// capabilityNames maps the bit position used by CapInh/CapPrm/CapEff/
+// CapBnd/CapAmb in /proc/self/status to its CAP_* name
+// (include/uapi/linux/capability.h).
+var capabilityNames = map[uint]string{
+	0:  "chown",
+	1:  "dac_override",
+	2:  "dac_read_search",
+	3:  "fowner",
+	4:  "fsetid",
+	5:  "kill",
+	6:  "setgid",
+	7:  "setuid",
+	8:  "setpcap",
+	9:  "linux_immutable",
+	10: "net_bind_service",
+	11: "net_broadcast",
+	12: "net_admin",
+	13: "net_raw",
+	14: "ipc_lock",
+	15: "ipc_owner",
+	16: "sys_module",
+	17: "sys_rawio",
+	18: "sys_chroot",
+	19: "sys_ptrace",
+	20: "sys_pacct",
+	21: "sys_admin",
+	22: "sys_boot",
+	23: "sys_nice",
+	24: "sys_resource",
+	25: "sys_time",
+	26: "sys_tty_config",
+	27: "mknod",
+	28: "lease",
+	29: "audit_write",
+	30: "audit_control",
+	31: "setfcap",
+	32: "mac_override",
+	33: "mac_admin",
+	34: "syslog",
+	35: "wake_alarm",
+	36: "block_suspend",
+	37: "audit_read",
+	38: "perfmon",
+	39: "bpf",
+	40: "checkpoint_restore",
+}
+
+// dockerDefaultRetainedCapabilities are the 14 capabilities Docker keeps
+// by default (everything else is dropped); containerd/CRI runtimes ship an
+// equivalent default set. A capability retained beyond this list is
+// "extra-privileged" relative to the out-of-the-box runtime.
+var dockerDefaultRetainedCapabilities = map[string]bool{
+	"chown":            true,
+	"dac_override":     true,
+	"fowner":           true,
+	"fsetid":           true,
+	"kill":             true,
+	"mknod":            true,
+	"net_bind_service": true,
+	"net_raw":          true,
+	"setfcap":          true,
+	"setgid":           true,
+	"setpcap":          true,
+	"setuid":           true,
+	"sys_chroot":       true,
+	"audit_write":      true,
+}
+
+// cgroupV1ReleaseAgentGlob matches the per-subsystem locations of the
+// cgroup v1 release_agent file, writable only with CAP_SYS_ADMIN over the
+// cgroup namespace; writing it is a well-known container-to-host escape
+// primitive (it runs an arbitrary host binary once the cgroup empties).
+const cgroupV1ReleaseAgentGlob = "/sys/fs/cgroup/*/release_agent"
+
+// CheckCapabilities parses the process's capability sets and NoNewPrivs
+// out of /proc/self/status, decodes them against the full CAP_* table, and
+// flags anything retained beyond the container runtime's default drop
+// list plus any combination of retained capability and reachable syscall
+// known to enable a container escape.
+func CheckCapabilities() []Finding {
+	fields, err := readCapabilityStatusFields()
+	if err != nil {
+		return []Finding{{Severity: SeverityInfo, Title: fmt.Sprintf("unable to read /proc/self/status: %v", err)}}
+	}
+
+	var findings []Finding
+
+	eff, effOK := decodeCapMask(fields["CapEff"])
+	bnd, bndOK := decodeCapMask(fields["CapBnd"])
+	if !effOK {
+		return append(findings, Finding{Severity: SeverityInfo, Title: "CapEff not found in /proc/self/status"})
+	}
+
+	findings = append(findings, Finding{
+		Severity: SeverityInfo,
+		Title:    "process capability sets",
+		Evidence: map[string]string{
+			"CapInh":     fields["CapInh"],
+			"CapPrm":     fields["CapPrm"],
+			"CapEff":     fields["CapEff"],
+			"CapBnd":     fields["CapBnd"],
+			"CapAmb":     fields["CapAmb"],
+			"NoNewPrivs": fields["NoNewPrivs"],
+			"effective":  strings.Join(capNames(eff), ", "),
+		},
+	})
+
+	if bndOK {
+		regainable := capNames(bnd &^ eff)
+		if len(regainable) > 0 {
+			findings = append(findings, Finding{
+				Severity:    SeverityMedium,
+				Title:       "capabilities present in the bounding set but not currently effective",
+				Evidence:    map[string]string{"capabilities": strings.Join(regainable, ", ")},
+				Remediation: "a setuid/file-capability binary could still re-acquire these; drop them from CapBnd if unneeded",
+			})
+		}
+	}
+
+	extra := extraCapabilities(eff)
+	if len(extra) == 0 {
+		findings = append(findings, Finding{Severity: SeverityInfo, Title: "no capabilities retained beyond the container runtime default"})
+	} else {
+		findings = append(findings, Finding{
+			Severity:    SeverityHigh,
+			Title:       "extra-privileged: capabilities retained beyond the container runtime default",
+			Evidence:    map[string]string{"capabilities": strings.Join(extra, ", ")},
+			Remediation: "drop these with --cap-drop or remove them from the pod's securityContext.capabilities",
+		})
+	}
+
+	findings = append(findings, dangerousCapabilityCombos(eff)...)
+
+	return findings
+}
+
+// readCapabilityStatusFields extracts the Cap*/NoNewPrivs lines from
+// /proc/self/status as raw strings, leaving hex decoding to the caller.
+func readCapabilityStatusFields() (map[string]string, error) {
+	f, err := os.Open("/proc/self/status")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	wanted := map[string]bool{"CapInh": true, "CapPrm": true, "CapEff": true, "CapBnd": true, "CapAmb": true, "NoNewPrivs": true}
+	fields := map[string]string{}
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		key := strings.TrimSpace(parts[0])
+		if !wanted[key] {
+			continue
+		}
+		fields[key] = strings.TrimSpace(parts[1])
+	}
+	return fields, scanner.Err()
+}
+
+// decodeCapMask parses a /proc/self/status capability field (a hex
+// bitmask, e.g. "0000000000000400") into its bit representation.
+func decodeCapMask(hex string) (uint64, bool) {
+	if hex == "" {
+		return 0, false
+	}
+	v, err := strconv.ParseUint(hex, 16, 64)
+	if err != nil {
+		return 0, false
+	}
+	return v, true
+}
+
+// capNames decodes a capability bitmask into its sorted CAP_* names.
+func capNames(mask uint64) []string {
+	var names []string
+	for bit := uint(0); bit < 64; bit++ {
+		if mask&(1<<bit) == 0 {
+			continue
+		}
+		if name, ok := capabilityNames[bit]; ok {
+			names = append(names, name)
+		} else {
+			names = append(names, fmt.Sprintf("unknown(%d)", bit))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// extraCapabilities returns the effective capabilities not present in the
+// container runtime's default retained set.
+func extraCapabilities(eff uint64) []string {
+	var extra []string
+	for _, name := range capNames(eff) {
+		if !dockerDefaultRetainedCapabilities[name] {
+			extra = append(extra, name)
+		}
+	}
+	sort.Strings(extra)
+	return extra
+}
+
+// dangerousCapabilityCombos flags capability + reachable-primitive pairs
+// known to enable a container escape outright.
+func dangerousCapabilityCombos(eff uint64) []Finding {
+	effNames := map[string]bool{}
+	for _, n := range capNames(eff) {
+		effNames[n] = true
+	}
+
+	var findings []Finding
+
+	if effNames["sys_admin"] {
+		if paths := writableCgroupReleaseAgents(); len(paths) > 0 {
+			findings = append(findings, Finding{
+				Severity:    SeverityCritical,
+				Title:       "CAP_SYS_ADMIN with a writable cgroup v1 release_agent - container escape available",
+				Evidence:    map[string]string{"release_agent_paths": strings.Join(paths, ", ")},
+				Remediation: "drop CAP_SYS_ADMIN or mount cgroups read-only; see CDK's cgroup release_agent exploit module (cdk run mount-cgroup)",
+			})
+		}
+	}
+
+	if effNames["dac_read_search"] {
+		if allowed, ok := isSyscallAllowed("open_by_handle_at"); ok && allowed {
+			findings = append(findings, Finding{
+				Severity:    SeverityCritical,
+				Title:       "CAP_DAC_READ_SEARCH with open_by_handle_at reachable - arbitrary host file read available",
+				Evidence:    map[string]string{"syscall": "open_by_handle_at"},
+				Remediation: "drop CAP_DAC_READ_SEARCH or seccomp-block open_by_handle_at; see CDK's DAC_READ_SEARCH exploit module",
+			})
+		}
+	}
+
+	return findings
+}
+
+// writableCgroupReleaseAgents returns every cgroup v1 release_agent file
+// CDK can write to.
+func writableCgroupReleaseAgents() []string {
+	matches, err := filepath.Glob(cgroupV1ReleaseAgentGlob)
+	if err != nil {
+		return nil
+	}
+	var writable []string
+	for _, path := range matches {
+		if unix.Access(path, unix.W_OK) == nil {
+			writable = append(writable, path)
+		}
+	}
+	return writable
+}
+
+// isSyscallAllowed reports whether the given syscall resolves to an ALLOW
+// verdict under the process's installed seccomp BPF program, reusing the
+// decoder CheckSeccompFilterAnalysis uses. ok is false when CDK cannot
+// determine an answer (no filter installed, unsupported arch, ...).
+func isSyscallAllowed(name string) (allowed bool, ok bool) {
+	if mode, present := seccompStatusMode(); !present || mode != "2" {
+		// Strict mode allows nothing beyond read/write/exit/sigreturn;
+		// disabled Seccomp or unknown status allows everything.
+		return mode != "1", mode != ""
+	}
+
+	arch, ok := auditArchForGOARCH[runtime.GOARCH]
+	if !ok {
+		return false, false
+	}
+	table := syscallTableForArch(runtime.GOARCH)
+	nr, ok := table[name]
+	if !ok {
+		return false, false
+	}
+	programs, err := dumpSeccompFilters()
+	if err != nil || len(programs) == 0 {
+		return false, false
+	}
+	return evalSeccompPrograms(programs, arch, nr).action == "ALLOW", true
+}
+
+func init() {
+	RegisterSimpleCheck(CategorySecurity, "security.capabilities", "Decode process capability sets and flag extra-privileged or escape-enabling combinations", CheckCapabilities)
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2022 The Authors of https://github.com/CDK-TEAM/CDK .
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evaluate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeCapMask(t *testing.T) {
+	v, ok := decodeCapMask("0000000000000400")
+	assert.True(t, ok)
+	assert.Equal(t, uint64(0x400), v)
+
+	_, ok = decodeCapMask("")
+	assert.False(t, ok)
+
+	_, ok = decodeCapMask("not-hex")
+	assert.False(t, ok)
+}
+
+func TestCapNames(t *testing.T) {
+	// bit 0 is chown, bit 21 is sys_admin.
+	mask := uint64(1<<0 | 1<<21)
+	assert.Equal(t, []string{"chown", "sys_admin"}, capNames(mask))
+}
+
+func TestCapNames_UnknownBit(t *testing.T) {
+	// bit 41 has no entry in capabilityNames.
+	assert.Equal(t, []string{"unknown(41)"}, capNames(1<<41))
+}
+
+func TestExtraCapabilities(t *testing.T) {
+	effective := uint64(0)
+	for bit := range capabilityNames {
+		effective |= 1 << bit
+	}
+	extra := extraCapabilities(effective)
+	for _, name := range extra {
+		assert.False(t, dockerDefaultRetainedCapabilities[name], "%s should not be reported as extra, it is in the default retained set", name)
+	}
+	assert.Contains(t, extra, "sys_admin")
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2022 The Authors of https://github.com/CDK-TEAM/CDK .
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evaluate
+
+// syscallNumbersAMD64 and syscallNumbersARM64 hold the syscalls
+// dockerDefaultBlockedSyscalls denies, plus a sample of syscalls the
+// default profile leaves allowed (clone, execve, socket, ...). The second
+// group exists so CheckSeccompFilterAnalysis's "blocked beyond default"
+// direction of the diff has something to find: without it, every name this
+// check ever evaluates is also a key of dockerDefaultBlockedSyscalls, which
+// makes that branch dead code. They are still not a full syscall table for
+// either ABI.
+//
+// Numbers are taken from arch/x86/entry/syscalls/syscall_64.tbl and
+// include/uapi/asm-generic/unistd.h (arm64 uses the generic table).
+var syscallNumbersAMD64 = map[string]int32{
+	"acct":              163,
+	"add_key":           248,
+	"bpf":               321,
+	"capset":            126,
+	"chroot":            161,
+	"clock_adjtime":     305,
+	"clone":             56,
+	"create_module":     174,
+	"delete_module":     176,
+	"execve":            59,
+	"finit_module":      313,
+	"get_kernel_syms":   177,
+	"init_module":       175,
+	"ioperm":            173,
+	"iopl":              172,
+	"kcmp":              312,
+	"kexec_file_load":   320,
+	"kexec_load":        246,
+	"keyctl":            250,
+	"lookup_dcookie":    212,
+	"mbind":             237,
+	"memfd_create":      319,
+	"mount":             165,
+	"move_pages":        279,
+	"name_to_handle_at": 303,
+	"nfsservctl":        180,
+	"open_by_handle_at": 304,
+	"perf_event_open":   298,
+	"personality":       135,
+	"pivot_root":        155,
+	"prctl":             157,
+	"process_vm_readv":  310,
+	"process_vm_writev": 311,
+	"ptrace":            101,
+	"query_module":      178,
+	"quotactl":          179,
+	"reboot":            169,
+	"request_key":       249,
+	"seccomp":           317,
+	"set_mempolicy":     238,
+	"setns":             308,
+	"settimeofday":      164,
+	"socket":            41,
+	"swapoff":           168,
+	"swapon":            167,
+	"sysfs":             139,
+	"_sysctl":           156,
+	"umount2":           166,
+	"unshare":           272,
+	"uselib":            134,
+	"userfaultfd":       323,
+	"ustat":             136,
+}
+
+var syscallNumbersARM64 = map[string]int32{
+	"acct":              89,
+	"add_key":           217,
+	"bpf":               280,
+	"capset":            91,
+	"chroot":            51,
+	"clock_adjtime":     266,
+	"clone":             220,
+	"delete_module":     106,
+	"execve":            221,
+	"finit_module":      273,
+	"init_module":       105,
+	"ioprio_set":        30,
+	"kcmp":              272,
+	"kexec_file_load":   294,
+	"kexec_load":        104,
+	"keyctl":            219,
+	"lookup_dcookie":    18,
+	"mbind":             235,
+	"memfd_create":      279,
+	"mount":             40,
+	"move_pages":        239,
+	"name_to_handle_at": 264,
+	"open_by_handle_at": 265,
+	"perf_event_open":   241,
+	"personality":       92,
+	"pivot_root":        41,
+	"prctl":             167,
+	"process_vm_readv":  270,
+	"process_vm_writev": 271,
+	"ptrace":            117,
+	"quotactl":          60,
+	"reboot":            142,
+	"request_key":       218,
+	"seccomp":           277,
+	"set_mempolicy":     237,
+	"setns":             268,
+	"settimeofday":      170,
+	"socket":            198,
+	"swapoff":           225,
+	"swapon":            224,
+	"umount2":           39,
+	"unshare":           97,
+	"userfaultfd":       282,
+}
+
+// syscallTableForArch returns the syscall-name-to-number table for the given
+// runtime.GOARCH value, or nil if CDK does not know the ABI.
+func syscallTableForArch(goarch string) map[string]int32 {
+	switch goarch {
+	case "amd64":
+		return syscallNumbersAMD64
+	case "arm64":
+		return syscallNumbersARM64
+	default:
+		return nil
+	}
+}
+
+// dockerDefaultBlockedSyscalls lists the syscalls Docker's bundled
+// default.json seccomp profile denies (and containerd/CRI ship an
+// equivalent deny set for) even though they are not blocked by any other
+// default confinement layer. A container whose own filter allows one of
+// these is more permissive than the out-of-the-box runtime; a container
+// that additionally blocks a syscall outside this set is more restrictive.
+var dockerDefaultBlockedSyscalls = map[string]bool{
+	"acct":              true,
+	"add_key":           true,
+	"bpf":               true,
+	"clock_adjtime":     true,
+	"create_module":     true,
+	"delete_module":     true,
+	"finit_module":      true,
+	"get_kernel_syms":   true,
+	"init_module":       true,
+	"ioperm":            true,
+	"iopl":              true,
+	"kcmp":              true,
+	"kexec_file_load":   true,
+	"kexec_load":        true,
+	"keyctl":            true,
+	"lookup_dcookie":    true,
+	"mbind":             true,
+	"mount":             true,
+	"move_pages":        true,
+	"name_to_handle_at": true,
+	"nfsservctl":        true,
+	"open_by_handle_at": true,
+	"perf_event_open":   true,
+	"pivot_root":        true,
+	"process_vm_readv":  true,
+	"process_vm_writev": true,
+	"ptrace":            true,
+	"query_module":      true,
+	"quotactl":          true,
+	"reboot":            true,
+	"request_key":       true,
+	"set_mempolicy":     true,
+	"setns":             true,
+	"settimeofday":      true,
+	"swapoff":           true,
+	"swapon":            true,
+	"sysfs":             true,
+	"_sysctl":           true,
+	"umount2":           true,
+	"uselib":            true,
+	"userfaultfd":       true,
+	"ustat":             true,
+}
@@ -0,0 +1,203 @@
+/*
+Copyright 2022 The Authors of https://github.com/CDK-TEAM/CDK .
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evaluate
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// namespacePeerSampleSize caps how many peer PIDs a Finding's evidence
+// lists, so a shared host PID namespace with thousands of processes
+// doesn't blow up the report.
+const namespacePeerSampleSize = 5
+
+// namespacePeerGroup is every process CDK can see in /proc that shares one
+// of the container's namespaces, classified by whose control group they
+// belong to.
+type namespacePeerGroup struct {
+	namespace      string
+	inode          string
+	total          int
+	samplePIDs     []int
+	hostInitPeers  int
+	otherContainer int
+}
+
+// CheckNamespaceIsolation compares /proc/1/ns/<ns> and /proc/self/ns/<ns>
+// for each namespace type and, when the namespace is shared with the host,
+// walks every PID visible under /proc to size up exactly who else is in
+// it - the first step towards an nsenter/setns escape through a sibling
+// process.
+func CheckNamespaceIsolation() []Finding {
+	var findings []Finding
+	for _, ns := range namespaceTypes {
+		initTarget, err1 := os.Readlink(fmt.Sprintf("/proc/1/ns/%s", ns))
+		selfTarget, err2 := os.Readlink(fmt.Sprintf("/proc/self/ns/%s", ns))
+		if err1 != nil || err2 != nil {
+			findings = append(findings, Finding{
+				Severity: SeverityInfo,
+				Title:    fmt.Sprintf("unable to read %s namespace links", ns),
+			})
+			continue
+		}
+		if initTarget != selfTarget {
+			findings = append(findings, Finding{
+				Severity: SeverityInfo,
+				Title:    fmt.Sprintf("%s namespace is isolated", ns),
+				Evidence: map[string]string{"namespace": ns, "target": selfTarget},
+			})
+			continue
+		}
+
+		findings = append(findings, Finding{
+			Severity:    SeverityHigh,
+			Title:       fmt.Sprintf("%s namespace is NOT isolated (shared with host)", ns),
+			Evidence:    map[string]string{"namespace": ns, "target": selfTarget},
+			Remediation: fmt.Sprintf("run the container with its own %s namespace instead of --pid=host/--net=host/--ipc=host", ns),
+		})
+		findings = append(findings, namespacePeerFinding(ns, selfTarget))
+	}
+	return findings
+}
+
+// namespacePeerFinding groups every /proc/<pid>/ns/<ns> that resolves to
+// the same namespace inode as the caller and reports how many belong to
+// the host's init cgroup or to a sibling container, which is exactly the
+// population an nsenter/setns escape would target.
+func namespacePeerFinding(ns, selfTarget string) Finding {
+	group, err := scanNamespacePeers(ns, selfTarget)
+	if err != nil {
+		return Finding{Severity: SeverityInfo, Title: fmt.Sprintf("unable to enumerate %s namespace peers: %v", ns, err)}
+	}
+
+	sample := make([]string, len(group.samplePIDs))
+	for i, pid := range group.samplePIDs {
+		sample[i] = strconv.Itoa(pid)
+	}
+
+	return Finding{
+		Severity: SeverityHigh,
+		Title:    fmt.Sprintf("%s namespace has %d other visible peer process(es)", ns, group.total),
+		Evidence: map[string]string{
+			"namespace":                 ns,
+			"inode":                     group.inode,
+			"peer_count":                strconv.Itoa(group.total),
+			"sample_pids":               strings.Join(sample, ", "),
+			"peers_in_host_init_cgroup": strconv.Itoa(group.hostInitPeers),
+			"peers_in_other_container":  strconv.Itoa(group.otherContainer),
+		},
+		Remediation: "do not share this namespace with the host or other pods unless strictly required",
+	}
+}
+
+// scanNamespacePeers walks /proc/<pid>/ns/<ns> for every PID currently
+// visible under /proc (which requires /proc to show the host's or another
+// container's processes, e.g. via hostPID or a shared PID namespace) and
+// collects every one whose namespace inode matches selfTarget.
+func scanNamespacePeers(ns, selfTarget string) (namespacePeerGroup, error) {
+	group := namespacePeerGroup{namespace: ns, inode: namespaceInode(selfTarget)}
+
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return group, err
+	}
+
+	selfPid := os.Getpid()
+	ownCgroup, _ := readCgroup(selfPid)
+
+	// /proc/1 is only the host's init when the pid namespace itself is
+	// shared with the host (e.g. --pid=host). If only some other namespace
+	// is shared (say --net=host with the pid namespace left isolated),
+	// /proc/1 is this container's own init, and comparing peer cgroups
+	// against it would misclassify every ordinary sibling process in our
+	// own container as sharing a cgroup with the host. Only compare against
+	// /proc/1's cgroup when we know pid itself is shared; otherwise peers
+	// can only be bucketed as "in our own container" or "elsewhere".
+	var hostCgroup string
+	if ns == "pid" || pidNamespaceShared() {
+		hostCgroup, _ = readCgroup(1)
+	}
+
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		if pid == selfPid {
+			continue
+		}
+		target, err := os.Readlink(fmt.Sprintf("/proc/%d/ns/%s", pid, ns))
+		if err != nil || target != selfTarget {
+			continue
+		}
+
+		group.total++
+		if len(group.samplePIDs) < namespacePeerSampleSize {
+			group.samplePIDs = append(group.samplePIDs, pid)
+		}
+
+		peerCgroup, err := readCgroup(pid)
+		switch {
+		case err != nil:
+			// unreadable cgroup: neither bucket, but still counted in total.
+		case hostCgroup != "" && peerCgroup == hostCgroup:
+			group.hostInitPeers++
+		case peerCgroup != ownCgroup:
+			group.otherContainer++
+		}
+	}
+
+	sort.Ints(group.samplePIDs)
+	return group, nil
+}
+
+// pidNamespaceShared reports whether the pid namespace itself is shared
+// with the host, independent of which namespace scanNamespacePeers is
+// currently evaluating. Peer cgroup classification for any other shared
+// namespace type needs this to decide whether /proc/1 is actually the
+// host's init.
+func pidNamespaceShared() bool {
+	initTarget, err1 := os.Readlink("/proc/1/ns/pid")
+	selfTarget, err2 := os.Readlink("/proc/self/ns/pid")
+	return err1 == nil && err2 == nil && initTarget == selfTarget
+}
+
+// namespaceInode extracts the inode number out of a namespace symlink
+// target of the form "net:[4026531840]".
+func namespaceInode(target string) string {
+	start := strings.IndexByte(target, '[')
+	end := strings.IndexByte(target, ']')
+	if start < 0 || end < 0 || end <= start {
+		return ""
+	}
+	return target[start+1 : end]
+}
+
+// readCgroup returns the raw contents of /proc/<pid>/cgroup, which CDK
+// uses as a coarse "which container is this process in" fingerprint: two
+// processes in the same container report identical cgroup paths.
+func readCgroup(pid int) (string, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/cgroup", pid))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2022 The Authors of https://github.com/CDK-TEAM/CDK .
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package evaluate groups CDK's read-only "what does this environment let
+// me get away with" checks: namespace isolation, Seccomp/AppArmor/SELinux
+// confinement, capabilities, and related host-escape surface analysis.
+package evaluate
+
+// Category groups related checks for reporting purposes.
+type Category string
+
+// CategorySecurity covers the Linux confinement checks in this package
+// (namespaces, Seccomp, AppArmor, SELinux, capabilities).
+const CategorySecurity Category = "security"
+
+// Severity is the relative risk a Finding represents. When emitting SARIF,
+// Report folds the five severities onto SARIF's three levels (see
+// sarifLevel).
+type Severity string
+
+const (
+	SeverityInfo     Severity = "info"
+	SeverityLow      Severity = "low"
+	SeverityMedium   Severity = "medium"
+	SeverityHigh     Severity = "high"
+	SeverityCritical Severity = "critical"
+)
+
+// Finding is one structured result produced by a check. Evidence holds the
+// raw facts (a proc file's contents, a decoded syscall verdict, ...) a
+// human or a downstream tool needs to corroborate Title without re-running
+// the check.
+type Finding struct {
+	Category    Category          `json:"category"`
+	ID          string            `json:"id"`
+	Severity    Severity          `json:"severity"`
+	Title       string            `json:"title"`
+	Evidence    map[string]string `json:"evidence,omitempty"`
+	Remediation string            `json:"remediation,omitempty"`
+}
+
+// CheckFunc is implemented by every registered check. A check that has
+// nothing to report returns nil, not a Finding with an empty Title.
+type CheckFunc func() []Finding
+
+// registeredCheck is one entry in the global check registry.
+type registeredCheck struct {
+	category    Category
+	id          string
+	description string
+	fn          CheckFunc
+}
+
+// registry holds every check registered via RegisterSimpleCheck, in
+// registration order, which CDK also uses as display/report order.
+var registry []registeredCheck
+
+// RegisterSimpleCheck registers fn under the given category and id so
+// RunAll picks it up. description is a short, human-readable summary kept
+// with the registration; no report sink prints it. Category and ID on findings fn
+// returns may be left zero-valued; RunAll fills them in from the
+// registration.
+func RegisterSimpleCheck(category Category, id, description string, fn CheckFunc) {
+	registry = append(registry, registeredCheck{category: category, id: id, description: description, fn: fn})
+}
+
+// RunAll executes every registered check, in registration order, and
+// collects their findings into a Report.
+func RunAll() *Report {
+	report := NewReport()
+	for _, c := range registry {
+		for _, f := range c.fn() {
+			if f.Category == "" {
+				f.Category = c.category
+			}
+			if f.ID == "" {
+				f.ID = c.id
+			}
+			report.Add(f)
+		}
+	}
+	return report
+}
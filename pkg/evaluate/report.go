@@ -0,0 +1,212 @@
+/*
+Copyright 2022 The Authors of https://github.com/CDK-TEAM/CDK .
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evaluate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Format selects how a Report is rendered. It is meant to back a top-level
+// "--format {text,json,sarif}" CLI flag (not yet wired up: this tree has no
+// cmd/main package to add one to), with a zero-value Format defaulting to
+// FormatText so existing output is unchanged for interactive use.
+type Format string
+
+const (
+	FormatText  Format = "text"
+	FormatJSON  Format = "json"
+	FormatSARIF Format = "sarif"
+)
+
+// ParseFormat validates a --format flag value.
+func ParseFormat(s string) (Format, error) {
+	switch Format(s) {
+	case FormatText, FormatJSON, FormatSARIF:
+		return Format(s), nil
+	default:
+		return "", fmt.Errorf("unknown report format %q (want text, json, or sarif)", s)
+	}
+}
+
+// Report aggregates Findings across every check RunAll executed so they can
+// be rendered as a single document.
+type Report struct {
+	Findings []Finding `json:"findings"`
+}
+
+// NewReport returns an empty Report ready for Add.
+func NewReport() *Report {
+	return &Report{}
+}
+
+// Add appends a finding to the report. A check that returns no findings
+// contributes nothing.
+func (r *Report) Add(f Finding) {
+	r.Findings = append(r.Findings, f)
+}
+
+// Write renders the report to w in the requested format.
+func (r *Report) Write(w io.Writer, format Format) error {
+	switch format {
+	case FormatText, "":
+		return r.writeText(w)
+	case FormatJSON:
+		return r.writeJSON(w)
+	case FormatSARIF:
+		return r.writeSARIF(w)
+	default:
+		return fmt.Errorf("unknown report format %q", format)
+	}
+}
+
+// writeText renders each finding as one "[severity] id: title" line,
+// followed by one indented line per evidence entry in sorted key order.
+// Findings are printed in the order they were added, not grouped.
+func (r *Report) writeText(w io.Writer) error {
+	for _, f := range r.Findings {
+		if len(f.Evidence) == 0 {
+			if _, err := fmt.Fprintf(w, "[%s] %s: %s\n", f.Severity, f.ID, f.Title); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "[%s] %s: %s\n", f.Severity, f.ID, f.Title); err != nil {
+			return err
+		}
+		for _, k := range sortedEvidenceKeys(f.Evidence) {
+			if _, err := fmt.Fprintf(w, "\t%s: %s\n", k, f.Evidence[k]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r *Report) writeJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// sarifReport, sarifRun, ... model the subset of SARIF 2.1.0
+// (https://docs.oasis-open.org/sarif/sarif/v2.1.0/) CDK needs: one tool
+// entry, one rule per distinct Finding.ID, one result per Finding.
+type sarifReport struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+// sarifLocation is included only to satisfy scanners that expect every
+// result to carry a physical location; CDK's checks inspect runtime state
+// rather than source files, so it points at the proc/sys path the evidence
+// came from when one is available.
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+func (r *Report) writeSARIF(w io.Writer) error {
+	doc := sarifReport{
+		Schema:  "https://raw.githubusercontent.com/oasis-tcs/sarif-spec/master/Schemata/sarif-schema-2.1.0.json",
+		Version: "2.1.0",
+	}
+	run := sarifRun{Tool: sarifTool{Driver: sarifDriver{Name: "cdk"}}}
+
+	seenRules := map[string]bool{}
+	for _, f := range r.Findings {
+		if !seenRules[f.ID] {
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: f.ID, Name: f.ID})
+			seenRules[f.ID] = true
+		}
+		result := sarifResult{
+			RuleID:  f.ID,
+			Level:   sarifLevel(f.Severity),
+			Message: sarifMessage{Text: f.Title},
+		}
+		if uri, ok := f.Evidence["path"]; ok {
+			result.Locations = []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: uri}}}}
+		}
+		run.Results = append(run.Results, result)
+	}
+	doc.Runs = []sarifRun{run}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// sarifLevel maps CDK's Severity onto SARIF's note/warning/error scale.
+func sarifLevel(s Severity) string {
+	switch s {
+	case SeverityHigh, SeverityCritical:
+		return "error"
+	case SeverityMedium:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+func sortedEvidenceKeys(evidence map[string]string) []string {
+	keys := make([]string, 0, len(evidence))
+	for k := range evidence {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
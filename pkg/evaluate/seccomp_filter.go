@@ -0,0 +1,486 @@
+/*
+Copyright 2022 The Authors of https://github.com/CDK-TEAM/CDK .
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evaluate
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// ptraceSeccompGetFilter is PTRACE_SECCOMP_GET_FILTER (linux/ptrace.h), added
+// in Linux 4.8. It is not exposed by golang.org/x/sys/unix, so it is issued
+// through a raw ptrace(2) syscall below.
+const ptraceSeccompGetFilter = 0x420c
+
+// Classic BPF opcode fields (linux/filter.h / linux/bpf_common.h) sufficient
+// to decode the small, straight-line programs libseccomp emits: a handful of
+// absolute loads from struct seccomp_data, comparisons against a constant,
+// and a terminating return.
+const (
+	bpfClassMask = 0x07
+	bpfLd        = 0x00
+	bpfJmp       = 0x05
+	bpfRet       = 0x06
+
+	bpfModeMask = 0xe0
+	bpfAbs      = 0x20
+
+	bpfOpMask = 0xf0
+	bpfJa     = 0x00
+	bpfJeq    = 0x10
+	bpfJgt    = 0x20
+	bpfJge    = 0x30
+	bpfJset   = 0x40
+
+	bpfSrcMask = 0x08
+	bpfSrcK    = 0x00
+
+	bpfRvalMask = 0x18
+)
+
+// Seccomp return actions (linux/seccomp.h). The low 16 bits of SECCOMP_RET_*
+// carry action-specific data (the errno for RET_ERRNO, the trace value for
+// RET_TRACE).
+const (
+	seccompRetKillProcess uint32 = 0x80000000
+	seccompRetKillThread  uint32 = 0x00000000
+	seccompRetTrap        uint32 = 0x00030000
+	seccompRetErrno       uint32 = 0x00050000
+	seccompRetUserNotif   uint32 = 0x7fc00000
+	seccompRetTrace       uint32 = 0x7ff00000
+	seccompRetLog         uint32 = 0x7ffc0000
+	seccompRetAllow       uint32 = 0x7fff0000
+
+	seccompRetActionMask uint32 = 0xffff0000
+	seccompRetDataMask   uint32 = 0x0000ffff
+)
+
+// seccompData mirrors struct seccomp_data, the value a BPF seccomp filter
+// receives as its (virtual) input buffer. Offsets match the kernel ABI:
+// nr(4) arch(4) instruction_pointer(8) args[6](8 each).
+type seccompData struct {
+	nr                 int32
+	arch               uint32
+	instructionPointer uint64
+	args               [6]uint64
+}
+
+const (
+	seccompDataOffNR   = 0
+	seccompDataOffArch = 4
+	seccompDataOffArg0 = 16
+)
+
+// auditArchForGOARCH maps runtime.GOARCH to the AUDIT_ARCH_* constant a
+// native seccomp filter compares seccomp_data.arch against.
+var auditArchForGOARCH = map[string]uint32{
+	"amd64": 0xc000003e, // AUDIT_ARCH_X86_64
+	"arm64": 0xc00000b7, // AUDIT_ARCH_AARCH64
+}
+
+// seccompVerdict is the terminating action a BPF program produces for a
+// given syscall number, reduced to the pieces CDK's diff cares about.
+type seccompVerdict struct {
+	action string
+	errno  uint32
+}
+
+func (v seccompVerdict) String() string {
+	if v.action == "ERRNO" {
+		return fmt.Sprintf("ERRNO(%d)", v.errno)
+	}
+	return v.action
+}
+
+func decodeSeccompAction(ret uint32) seccompVerdict {
+	switch ret & seccompRetActionMask {
+	case seccompRetAllow:
+		return seccompVerdict{action: "ALLOW"}
+	case seccompRetErrno:
+		return seccompVerdict{action: "ERRNO", errno: ret & seccompRetDataMask}
+	case seccompRetKillProcess:
+		return seccompVerdict{action: "KILL_PROCESS"}
+	case seccompRetTrap:
+		return seccompVerdict{action: "TRAP"}
+	case seccompRetTrace:
+		return seccompVerdict{action: "TRACE"}
+	case seccompRetLog:
+		return seccompVerdict{action: "LOG"}
+	case seccompRetUserNotif:
+		return seccompVerdict{action: "USER_NOTIF"}
+	default:
+		if ret == seccompRetKillThread {
+			return seccompVerdict{action: "KILL_THREAD"}
+		}
+		return seccompVerdict{action: "UNKNOWN"}
+	}
+}
+
+// CheckSeccompFilterAnalysis decodes the BPF program(s) installed on the
+// current process and reports, syscall by syscall, how the container's
+// seccomp profile diverges from the Docker/containerd default allow-list.
+// It only has anything to decode when CheckSeccompStatus reports filter
+// mode (2); strict mode and disabled Seccomp are left to that check.
+func CheckSeccompFilterAnalysis() []Finding {
+	if mode, ok := seccompStatusMode(); !ok || mode != "2" {
+		return []Finding{{Severity: SeverityInfo, Title: "Seccomp is not in filter mode, nothing to decode"}}
+	}
+
+	var findings []Finding
+	if n, ok := seccompFilterCount(); ok {
+		findings = append(findings, Finding{Severity: SeverityInfo, Title: "attached BPF program count", Evidence: map[string]string{"Seccomp_filters": fmt.Sprintf("%d", n)}})
+	}
+
+	arch, ok := auditArchForGOARCH[runtime.GOARCH]
+	if !ok {
+		return append(findings, Finding{Severity: SeverityInfo, Title: fmt.Sprintf("unsupported architecture %s, skipping BPF decode", runtime.GOARCH)})
+	}
+
+	programs, err := dumpSeccompFilters()
+	if err != nil {
+		return append(findings, Finding{
+			Severity: SeverityInfo,
+			Title:    fmt.Sprintf("unable to dump installed BPF program via ptrace: %v", err),
+			Evidence: map[string]string{"caveat": "ptrace is itself in the Docker/containerd default deny-list, so this technique cannot decode a container's own filter while it is still running under the stock default profile (or any profile that blocks ptrace)"},
+		})
+	}
+	if len(programs) == 0 {
+		return append(findings, Finding{Severity: SeverityInfo, Title: "no BPF program returned by the kernel (insufficient privilege?)"})
+	}
+
+	table := syscallTableForArch(runtime.GOARCH)
+	if len(table) == 0 {
+		return append(findings, Finding{Severity: SeverityInfo, Title: fmt.Sprintf("no syscall table available for %s", runtime.GOARCH)})
+	}
+
+	var blockedExtra, allowedExtra, undecodable []string
+	for name, nr := range table {
+		verdict := evalSeccompPrograms(programs, arch, nr)
+		inDockerDefault := dockerDefaultBlockedSyscalls[name]
+		switch {
+		case verdict.action == "UNKNOWN":
+			undecodable = append(undecodable, name)
+		case verdict.action == "ALLOW" && inDockerDefault:
+			allowedExtra = append(allowedExtra, name)
+		case verdict.action != "ALLOW" && !inDockerDefault:
+			blockedExtra = append(blockedExtra, name)
+		}
+	}
+	sort.Strings(allowedExtra)
+	sort.Strings(blockedExtra)
+	sort.Strings(undecodable)
+
+	if len(undecodable) > 0 {
+		findings = append(findings, Finding{
+			Severity:    SeverityMedium,
+			Title:       "some syscalls could not be decoded from the installed BPF program",
+			Evidence:    map[string]string{"syscalls": strings.Join(undecodable, ", ")},
+			Remediation: "treat these as unverified rather than assuming they are allowed; the filter uses an instruction this decoder does not support",
+		})
+	}
+
+	if len(allowedExtra) == 0 {
+		findings = append(findings, Finding{Severity: SeverityInfo, Title: "no extra syscalls allowed beyond the Docker default profile"})
+	} else {
+		findings = append(findings, Finding{
+			Severity:    SeverityHigh,
+			Title:       "extra syscalls allowed beyond the Docker default profile",
+			Evidence:    map[string]string{"syscalls": strings.Join(allowedExtra, ", ")},
+			Remediation: "tighten the container's seccomp profile to the Docker/containerd default allow-list",
+		})
+	}
+	if len(blockedExtra) == 0 {
+		findings = append(findings, Finding{Severity: SeverityInfo, Title: "no syscalls blocked beyond the Docker default profile"})
+	} else {
+		findings = append(findings, Finding{
+			Severity: SeverityInfo,
+			Title:    "syscalls blocked by this container beyond the Docker default profile",
+			Evidence: map[string]string{"syscalls": strings.Join(blockedExtra, ", ")},
+		})
+	}
+	return findings
+}
+
+// seccompStatusMode re-reads the Seccomp: value from /proc/self/status so
+// this check does not depend on CheckSeccompStatus having already run.
+func seccompStatusMode() (string, bool) {
+	data, err := os.ReadFile("/proc/self/status")
+	if err != nil {
+		return "", false
+	}
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "Seccomp:") {
+			fields := strings.Fields(line)
+			if len(fields) < 2 {
+				return "", false
+			}
+			return fields[1], true
+		}
+	}
+	return "", false
+}
+
+// seccompFilterCount reads Seccomp_filters from /proc/self/status, which
+// counts the BPF programs attached to the process (present since Linux 4.1).
+func seccompFilterCount() (int, bool) {
+	data, err := os.ReadFile("/proc/self/status")
+	if err != nil {
+		return 0, false
+	}
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "Seccomp_filters:") {
+			fields := strings.Fields(line)
+			if len(fields) < 2 {
+				return 0, false
+			}
+			var n int
+			if _, err := fmt.Sscanf(fields[1], "%d", &n); err != nil {
+				return 0, false
+			}
+			return n, true
+		}
+	}
+	return 0, false
+}
+
+// dumpSeccompFilters retrieves the BPF program(s) attached to the current
+// process. Seccomp filters survive fork(2) and execve(2), and a process
+// started with SysProcAttr.Ptrace is stopped by the kernel the instant
+// execve completes, before any of its own code (including main) ever runs.
+// Re-executing the running binary as such a traced child therefore yields a
+// live process carrying an identical copy of CDK's own BPF program; CDK
+// then uses PTRACE_SECCOMP_GET_FILTER (which the kernel only honours for a
+// tracer with CAP_SYS_ADMIN in the tracee's user namespace, or root)
+// against that child to read each program back without ever touching its
+// own enforcement.
+func dumpSeccompFilters() ([][]unix.SockFilter, error) {
+	exe, err := os.Readlink("/proc/self/exe")
+	if err != nil {
+		exe = os.Args[0]
+	}
+
+	cmd := exec.Command(exe)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Ptrace: true}
+
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("spawning traced probe: %w", err)
+	}
+	pid := cmd.Process.Pid
+	defer func() {
+		_ = cmd.Process.Kill()
+		_, _ = cmd.Process.Wait()
+	}()
+
+	var ws unix.WaitStatus
+	if _, err := unix.Wait4(pid, &ws, 0, nil); err != nil {
+		return nil, fmt.Errorf("waiting for probe to stop: %w", err)
+	}
+	if !ws.Stopped() {
+		return nil, fmt.Errorf("probe did not stop as expected, status=%v", ws)
+	}
+
+	var programs [][]unix.SockFilter
+	for idx := uint32(0); ; idx++ {
+		n, err := ptraceGetFilterLen(pid, idx)
+		if err != nil {
+			if idx == 0 {
+				return nil, fmt.Errorf("PTRACE_SECCOMP_GET_FILTER: %w", err)
+			}
+			break
+		}
+		filter := make([]unix.SockFilter, n)
+		if n > 0 {
+			if err := ptraceGetFilter(pid, idx, filter); err != nil {
+				return nil, fmt.Errorf("reading BPF program %d: %w", idx, err)
+			}
+		}
+		programs = append(programs, filter)
+	}
+	return programs, nil
+}
+
+// ptraceGetFilterLen issues PTRACE_SECCOMP_GET_FILTER with a NULL data
+// pointer, which per ptrace(2) returns the instruction count of filter
+// number idx instead of copying it out.
+func ptraceGetFilterLen(pid int, idx uint32) (int, error) {
+	n, _, errno := syscall.Syscall6(syscall.SYS_PTRACE, ptraceSeccompGetFilter, uintptr(pid), uintptr(idx), 0, 0, 0)
+	if errno != 0 {
+		return 0, errno
+	}
+	return int(n), nil
+}
+
+func ptraceGetFilter(pid int, idx uint32, out []unix.SockFilter) error {
+	var ptr uintptr
+	if len(out) > 0 {
+		ptr = uintptr(unsafe.Pointer(&out[0]))
+	}
+	_, _, errno := syscall.Syscall6(syscall.SYS_PTRACE, ptraceSeccompGetFilter, uintptr(pid), uintptr(idx), ptr, 0, 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// evalSeccompPrograms runs every attached BPF program against a synthetic
+// seccomp_data for (arch, nr) the way the kernel does: every program runs,
+// and the most restrictive action observed (the highest
+// seccompActionPriority) wins.
+//
+// If any program hits an instruction runBPF doesn't support, that program's
+// true verdict is unknown, and it would be wrong to let another program's
+// priority silently decide the overall result: the undecoded instruction
+// could just as easily have produced something more restrictive than
+// whatever this function would otherwise report. So UNKNOWN always wins,
+// regardless of what the other programs decided.
+func evalSeccompPrograms(programs [][]unix.SockFilter, arch uint32, nr int32) seccompVerdict {
+	data := seccompData{nr: nr, arch: arch}
+	verdict := seccompVerdict{action: "ALLOW"}
+	for _, prog := range programs {
+		v := runBPF(prog, data)
+		if v.action == "UNKNOWN" {
+			return v
+		}
+		if seccompActionPriority(v.action) > seccompActionPriority(verdict.action) {
+			verdict = v
+		}
+	}
+	return verdict
+}
+
+// seccompActionPriority orders actions the way the kernel's
+// seccomp_run_filters does: the most restrictive non-ALLOW action observed
+// across the attached programs is the one that applies.
+func seccompActionPriority(action string) int {
+	switch action {
+	case "ALLOW":
+		return 0
+	case "LOG":
+		return 1
+	case "TRACE":
+		return 2
+	case "USER_NOTIF":
+		return 3
+	case "ERRNO":
+		return 4
+	case "TRAP":
+		return 5
+	case "KILL_THREAD":
+		return 6
+	case "KILL_PROCESS":
+		return 7
+	default:
+		return 0
+	}
+}
+
+// runBPF interprets a classic BPF program against a seccomp_data input. It
+// supports the narrow instruction subset libseccomp and Docker's bundled
+// default.json compile to: absolute loads of nr/arch/args, K-constant
+// jumps (==, >, >=, bit-test), an unconditional jump, and a K-constant
+// return.
+func runBPF(prog []unix.SockFilter, data seccompData) seccompVerdict {
+	var a uint32
+	for pc := 0; pc < len(prog); pc++ {
+		ins := prog[pc]
+		class := ins.Code & bpfClassMask
+		switch class {
+		case bpfLd:
+			if ins.Code&bpfModeMask != bpfAbs {
+				return seccompVerdict{action: "UNKNOWN"}
+			}
+			a = loadSeccompDataWord(data, ins.K)
+		case bpfJmp:
+			op := ins.Code & bpfOpMask
+			if ins.Code&bpfSrcMask != bpfSrcK {
+				return seccompVerdict{action: "UNKNOWN"}
+			}
+			var taken bool
+			switch op {
+			case bpfJa:
+				pc += int(ins.K)
+				continue
+			case bpfJeq:
+				taken = a == ins.K
+			case bpfJgt:
+				taken = a > ins.K
+			case bpfJge:
+				taken = a >= ins.K
+			case bpfJset:
+				taken = a&ins.K != 0
+			default:
+				return seccompVerdict{action: "UNKNOWN"}
+			}
+			if taken {
+				pc += int(ins.Jt)
+			} else {
+				pc += int(ins.Jf)
+			}
+		case bpfRet:
+			if ins.Code&bpfRvalMask != bpfSrcK {
+				return seccompVerdict{action: "UNKNOWN"}
+			}
+			return decodeSeccompAction(ins.K)
+		default:
+			return seccompVerdict{action: "UNKNOWN"}
+		}
+	}
+	return seccompVerdict{action: "UNKNOWN"}
+}
+
+// loadSeccompDataWord emulates BPF_LD|BPF_W|BPF_ABS for the handful of
+// struct seccomp_data offsets real-world filters load from. args[] is
+// loaded 32 bits at a time (low word then high word), matching how
+// libseccomp-generated programs address 64-bit arguments on these ABIs.
+func loadSeccompDataWord(data seccompData, off uint32) uint32 {
+	switch {
+	case off == seccompDataOffNR:
+		return uint32(data.nr)
+	case off == seccompDataOffArch:
+		return data.arch
+	case off >= seccompDataOffArg0 && off < seccompDataOffArg0+8*6:
+		argIdx := (off - seccompDataOffArg0) / 8
+		wordOff := (off - seccompDataOffArg0) % 8
+		word := data.args[argIdx]
+		if wordOff == 0 {
+			return uint32(word)
+		}
+		return uint32(word >> 32)
+	default:
+		return 0
+	}
+}
+
+func init() {
+	RegisterSimpleCheck(CategorySecurity, "security.seccomp_filter_analysis", "Decode the installed seccomp BPF program and diff it against the Docker default profile", CheckSeccompFilterAnalysis)
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2022 The Authors of https://github.com/CDK-TEAM/CDK .
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evaluate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/sys/unix"
+)
+
+// allowProgram always returns ALLOW, regardless of input.
+var allowProgram = []unix.SockFilter{
+	{Code: bpfRet, K: seccompRetAllow},
+}
+
+// nrSplitProgram allows everything except a single syscall number, which it
+// errors out with errno 1 - the shape a real libseccomp-generated filter
+// takes for a handful of denied syscalls.
+func nrSplitProgram(blockedNR uint32) []unix.SockFilter {
+	return []unix.SockFilter{
+		{Code: bpfLd | bpfAbs, K: seccompDataOffNR},
+		{Code: bpfJmp | bpfJeq | bpfSrcK, Jt: 0, Jf: 1, K: blockedNR},
+		{Code: bpfRet, K: seccompRetErrno | 1},
+		{Code: bpfRet, K: seccompRetAllow},
+	}
+}
+
+func TestRunBPF_Allow(t *testing.T) {
+	v := runBPF(allowProgram, seccompData{nr: 59, arch: 0xc000003e})
+	assert.Equal(t, "ALLOW", v.action)
+}
+
+func TestRunBPF_NRSplit(t *testing.T) {
+	prog := nrSplitProgram(101)
+
+	blocked := runBPF(prog, seccompData{nr: 101, arch: 0xc000003e})
+	assert.Equal(t, "ERRNO", blocked.action)
+	assert.Equal(t, uint32(1), blocked.errno)
+
+	allowed := runBPF(prog, seccompData{nr: 59, arch: 0xc000003e})
+	assert.Equal(t, "ALLOW", allowed.action)
+}
+
+func TestRunBPF_UnsupportedInstructionIsUnknown(t *testing.T) {
+	// class 0x04 is BPF_ALU, which runBPF does not implement.
+	prog := []unix.SockFilter{{Code: 0x04}}
+	v := runBPF(prog, seccompData{nr: 0})
+	assert.Equal(t, "UNKNOWN", v.action)
+}
+
+func TestEvalSeccompPrograms_MostRestrictiveWins(t *testing.T) {
+	programs := [][]unix.SockFilter{allowProgram, nrSplitProgram(101)}
+	v := evalSeccompPrograms(programs, 0xc000003e, 101)
+	assert.Equal(t, "ERRNO", v.action)
+}
+
+func TestEvalSeccompPrograms_AllAllow(t *testing.T) {
+	programs := [][]unix.SockFilter{allowProgram, allowProgram}
+	v := evalSeccompPrograms(programs, 0xc000003e, 59)
+	assert.Equal(t, "ALLOW", v.action)
+}
+
+func TestEvalSeccompPrograms_UnknownOverridesAllow(t *testing.T) {
+	unknownProgram := []unix.SockFilter{{Code: 0x04}}
+	programs := [][]unix.SockFilter{allowProgram, unknownProgram}
+	v := evalSeccompPrograms(programs, 0xc000003e, 59)
+	assert.Equal(t, "UNKNOWN", v.action)
+}
+
+func TestDecodeSeccompAction(t *testing.T) {
+	assert.Equal(t, "ALLOW", decodeSeccompAction(seccompRetAllow).action)
+	assert.Equal(t, "KILL_PROCESS", decodeSeccompAction(seccompRetKillProcess).action)
+	assert.Equal(t, "KILL_THREAD", decodeSeccompAction(seccompRetKillThread).action)
+
+	errno := decodeSeccompAction(seccompRetErrno | 13)
+	assert.Equal(t, "ERRNO", errno.action)
+	assert.Equal(t, uint32(13), errno.errno)
+}
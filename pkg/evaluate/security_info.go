@@ -21,7 +21,6 @@ import (
 	"compress/gzip"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"os"
 	"strings"
 )
@@ -29,150 +28,155 @@ import (
 // namespaceTypes lists the Linux namespaces relevant to container isolation.
 var namespaceTypes = []string{"cgroup", "ipc", "mnt", "net", "pid", "uts"}
 
-// CheckNamespaceIsolation compares /proc/1/ns/<ns> and /proc/self/ns/<ns> for
-// each namespace type. If the symlink targets differ, the namespace is isolated.
-func CheckNamespaceIsolation() {
-	log.Println("Namespace isolation status:")
-	for _, ns := range namespaceTypes {
-		initTarget, err1 := os.Readlink(fmt.Sprintf("/proc/1/ns/%s", ns))
-		selfTarget, err2 := os.Readlink(fmt.Sprintf("/proc/self/ns/%s", ns))
-		if err1 != nil || err2 != nil {
-			log.Printf("\t%s: unable to read namespace links", ns)
-			continue
-		}
-		if initTarget != selfTarget {
-			fmt.Printf("\t%s: isolated (%s)\n", ns, selfTarget)
-		} else {
-			fmt.Printf("\t%s: NOT isolated (shared with host, %s)\n", ns, selfTarget)
-		}
-	}
-}
-
-// CheckSeccompStatus reads the Seccomp field from /proc/self/status and reports
-// whether Seccomp is disabled (0), strict (1), or filter (2) mode.
-func CheckSeccompStatus() {
+// CheckSeccompStatus reads the Seccomp field from /proc/self/status and
+// reports whether Seccomp is disabled (0), strict (1), or filter (2) mode.
+func CheckSeccompStatus() []Finding {
 	data, err := ioutil.ReadFile("/proc/self/status")
 	if err != nil {
-		log.Printf("seccomp: unable to read /proc/self/status: %v", err)
-		return
+		return []Finding{{Severity: SeverityInfo, Title: fmt.Sprintf("unable to read /proc/self/status: %v", err)}}
 	}
 
 	scanner := bufio.NewScanner(strings.NewReader(string(data)))
 	for scanner.Scan() {
 		line := scanner.Text()
-		if strings.HasPrefix(line, "Seccomp:") {
-			parts := strings.Fields(line)
-			if len(parts) < 2 {
-				log.Println("seccomp: malformed Seccomp line")
-				return
-			}
-			switch parts[1] {
-			case "0":
-				log.Println("Seccomp: disabled")
-			case "1":
-				log.Println("Seccomp: strict mode (1)")
-			case "2":
-				log.Println("Seccomp: filter mode (2)")
-			default:
-				log.Printf("Seccomp: unknown value %s", parts[1])
-			}
-			return
+		if !strings.HasPrefix(line, "Seccomp:") {
+			continue
+		}
+		parts := strings.Fields(line)
+		if len(parts) < 2 {
+			return []Finding{{Severity: SeverityInfo, Title: "malformed Seccomp line in /proc/self/status"}}
+		}
+		switch parts[1] {
+		case "0":
+			return []Finding{{
+				Severity:    SeverityHigh,
+				Title:       "Seccomp is disabled",
+				Evidence:    map[string]string{"mode": "0"},
+				Remediation: "run the container with a seccomp profile instead of --security-opt seccomp=unconfined",
+			}}
+		case "1":
+			return []Finding{{Severity: SeverityInfo, Title: "Seccomp is in strict mode (1)", Evidence: map[string]string{"mode": "1"}}}
+		case "2":
+			return []Finding{{Severity: SeverityInfo, Title: "Seccomp is in filter mode (2)", Evidence: map[string]string{"mode": "2"}}}
+		default:
+			return []Finding{{Severity: SeverityInfo, Title: fmt.Sprintf("unknown Seccomp value %s", parts[1])}}
 		}
 	}
-	log.Println("Seccomp: field not found in /proc/self/status (kernel may not support Seccomp)")
+	return []Finding{{Severity: SeverityInfo, Title: "Seccomp field not found in /proc/self/status (kernel may not support Seccomp)"}}
 }
 
-// CheckSeccompKernelSupport reports whether the running kernel was compiled with
-// Seccomp support by checking for the Seccomp field in /proc/self/status and,
-// optionally, the kernel config.
-func CheckSeccompKernelSupport() {
-	// The presence of the "Seccomp:" line in /proc/self/status indicates support.
+// CheckSeccompKernelSupport reports whether the running kernel was compiled
+// with Seccomp support by checking for the Seccomp field in
+// /proc/self/status and, optionally, the kernel config.
+func CheckSeccompKernelSupport() []Finding {
 	data, err := ioutil.ReadFile("/proc/self/status")
 	if err != nil {
-		log.Printf("seccomp: unable to read /proc/self/status: %v", err)
-		return
+		return []Finding{{Severity: SeverityInfo, Title: fmt.Sprintf("unable to read /proc/self/status: %v", err)}}
 	}
+
+	var findings []Finding
 	if strings.Contains(string(data), "Seccomp:") {
-		log.Println("Seccomp: kernel supports Seccomp")
+		findings = append(findings, Finding{Severity: SeverityInfo, Title: "kernel supports Seccomp"})
 	} else {
-		log.Println("Seccomp: kernel does NOT support Seccomp")
+		findings = append(findings, Finding{Severity: SeverityInfo, Title: "kernel does NOT support Seccomp"})
 	}
 
-	// Additional confirmation via kernel config when available.
 	if val, ok := readKernelConfigOption("CONFIG_SECCOMP"); ok {
-		log.Printf("Seccomp: kernel config CONFIG_SECCOMP=%s", val)
+		findings = append(findings, Finding{
+			Severity: SeverityInfo,
+			Title:    "kernel config CONFIG_SECCOMP",
+			Evidence: map[string]string{"CONFIG_SECCOMP": val},
+		})
 	}
+	return findings
 }
 
 // CheckSELinux detects whether SELinux is present and enforcing.
-func CheckSELinux() {
-	// /sys/fs/selinux/enforce exists only when SELinux is compiled in and mounted.
+func CheckSELinux() []Finding {
 	enforceFile := "/sys/fs/selinux/enforce"
 	data, err := ioutil.ReadFile(enforceFile)
 	if err != nil {
-		log.Println("SELinux: not detected (no selinuxfs)")
-		return
+		return []Finding{{Severity: SeverityInfo, Title: "SELinux not detected (no selinuxfs)"}}
 	}
+
+	var findings []Finding
 	switch strings.TrimSpace(string(data)) {
 	case "1":
-		log.Println("SELinux: enforcing")
+		findings = append(findings, Finding{Severity: SeverityInfo, Title: "SELinux is enforcing"})
 	case "0":
-		log.Println("SELinux: permissive (loaded but not enforcing)")
+		findings = append(findings, Finding{
+			Severity:    SeverityMedium,
+			Title:       "SELinux is permissive (loaded but not enforcing)",
+			Remediation: "set SELinux to enforcing, or run the container runtime with --selinux-enabled",
+		})
 	default:
-		log.Printf("SELinux: unexpected enforce value %q", strings.TrimSpace(string(data)))
+		findings = append(findings, Finding{Severity: SeverityInfo, Title: fmt.Sprintf("unexpected SELinux enforce value %q", strings.TrimSpace(string(data)))})
 	}
 
-	// Show the container's SELinux label if available.
 	if label, err := ioutil.ReadFile("/proc/self/attr/current"); err == nil {
 		trimmed := strings.TrimRight(string(label), "\x00\n")
-		log.Printf("SELinux: container label: %s", trimmed)
+		findings = append(findings, Finding{
+			Severity: SeverityInfo,
+			Title:    "container SELinux label",
+			Evidence: map[string]string{"label": trimmed, "path": "/proc/self/attr/current"},
+		})
 	}
+	return findings
 }
 
 // CheckAppArmor inspects kernel compile options, boot parameters, runtime
 // status, and the active AppArmor profile for the current process.
-func CheckAppArmor() {
+func CheckAppArmor() []Finding {
+	var findings []Finding
+
 	// 1. Kernel compile option.
 	if val, ok := readKernelConfigOption("CONFIG_SECURITY_APPARMOR"); ok {
-		log.Printf("AppArmor: kernel config CONFIG_SECURITY_APPARMOR=%s", val)
+		findings = append(findings, Finding{Severity: SeverityInfo, Title: "kernel config CONFIG_SECURITY_APPARMOR", Evidence: map[string]string{"CONFIG_SECURITY_APPARMOR": val}})
 	} else {
-		log.Println("AppArmor: kernel config not available")
+		findings = append(findings, Finding{Severity: SeverityInfo, Title: "AppArmor kernel config not available"})
 	}
 
 	// 2. Boot parameters.
 	if cmdline, err := ioutil.ReadFile("/proc/cmdline"); err == nil {
 		params := string(cmdline)
-		if strings.Contains(params, "apparmor=1") || strings.Contains(params, "security=apparmor") {
-			log.Printf("AppArmor: enabled via boot parameters (%s)", strings.TrimSpace(params))
-		} else if strings.Contains(params, "apparmor=0") {
-			log.Println("AppArmor: disabled via boot parameter apparmor=0")
-		} else {
-			log.Println("AppArmor: no explicit AppArmor boot parameter found")
+		switch {
+		case strings.Contains(params, "apparmor=1") || strings.Contains(params, "security=apparmor"):
+			findings = append(findings, Finding{Severity: SeverityInfo, Title: "AppArmor enabled via boot parameters", Evidence: map[string]string{"cmdline": strings.TrimSpace(params)}})
+		case strings.Contains(params, "apparmor=0"):
+			findings = append(findings, Finding{Severity: SeverityMedium, Title: "AppArmor disabled via boot parameter apparmor=0"})
+		default:
+			findings = append(findings, Finding{Severity: SeverityInfo, Title: "no explicit AppArmor boot parameter found"})
 		}
 	}
 
 	// 3. Runtime status.
 	if data, err := ioutil.ReadFile("/sys/module/apparmor/parameters/enabled"); err == nil {
 		if strings.TrimSpace(string(data)) == "Y" {
-			log.Println("AppArmor: module is enabled (runtime)")
+			findings = append(findings, Finding{Severity: SeverityInfo, Title: "AppArmor module is enabled (runtime)"})
 		} else {
-			log.Println("AppArmor: module is loaded but disabled (runtime)")
+			findings = append(findings, Finding{Severity: SeverityMedium, Title: "AppArmor module is loaded but disabled (runtime)"})
 		}
 	} else {
-		log.Println("AppArmor: module not loaded")
+		findings = append(findings, Finding{Severity: SeverityInfo, Title: "AppArmor module not loaded"})
 	}
 
 	// 4. Container AppArmor profile.
 	if label, err := ioutil.ReadFile("/proc/self/attr/current"); err == nil {
 		trimmed := strings.TrimRight(string(label), "\x00\n")
 		if trimmed == "" || trimmed == "unconfined" {
-			log.Println("AppArmor: container is unconfined (no profile attached)")
+			findings = append(findings, Finding{
+				Severity:    SeverityMedium,
+				Title:       "container is unconfined (no AppArmor profile attached)",
+				Remediation: "run the container with --security-opt apparmor=docker-default or an equivalent profile",
+			})
 		} else {
-			log.Printf("AppArmor: container profile: %s", trimmed)
+			findings = append(findings, Finding{Severity: SeverityInfo, Title: "container AppArmor profile", Evidence: map[string]string{"profile": trimmed, "path": "/proc/self/attr/current"}})
 		}
 	} else {
-		log.Println("AppArmor: unable to read container profile")
+		findings = append(findings, Finding{Severity: SeverityInfo, Title: "unable to read container AppArmor profile"})
 	}
+
+	return findings
 }
 
 // readKernelConfigOption searches the kernel config (compressed or plain) for
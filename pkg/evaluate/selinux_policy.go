@@ -0,0 +1,96 @@
+/*
+Copyright 2022 The Authors of https://github.com/CDK-TEAM/CDK .
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evaluate
+
+import (
+	"os"
+	"strings"
+)
+
+// CheckSELinuxPolicyDiff reports whether SELinux is enforcing and whether
+// the container's own type is spc_t (the super-privileged container type,
+// which carries policy to act on the whole host and is a well-known way
+// confinement gets silently dropped).
+//
+// An earlier version of this check tried to decode type-enforcement allow
+// rules directly out of the binary policy at /sys/fs/selinux/policy. That
+// requires correctly parsing every symbol table kind policydb_read uses
+// (commons, classes, roles, types, users, booleans, MLS levels/categories),
+// and several of those - commons, roles, users - embed their own
+// variable-length nested structures (per-common permission symtabs,
+// ebitmaps) rather than one fixed-width trailing field the way classes and
+// types do. CDK has no reference policy blob to validate a hand-rolled
+// decoder against, and a decoder that misjudges one of those layouts
+// doesn't fail loudly: it desyncs the read cursor and then trusts a
+// now-garbage length prefix for every subsequent field, which can hang or
+// crash the process reading its own policy. Until that can be verified
+// against real fixtures (or replaced with an existing policydb library),
+// this check is limited to the selinuxfs enforce file and the process's
+// context in /proc/self/attr/current rather than the raw binary policy.
+func CheckSELinuxPolicyDiff() []Finding {
+	enforce, err := os.ReadFile("/sys/fs/selinux/enforce")
+	if err != nil {
+		return []Finding{{Severity: SeverityInfo, Title: "selinuxfs not mounted, skipping policy analysis"}}
+	}
+
+	selfType, ok := selinuxCurrentType()
+	if !ok {
+		return []Finding{{Severity: SeverityInfo, Title: "unable to determine the process's current SELinux type"}}
+	}
+
+	findings := []Finding{{
+		Severity: SeverityInfo,
+		Title:    "container SELinux type",
+		Evidence: map[string]string{"type": selfType, "path": "/proc/self/attr/current", "enforce": strings.TrimSpace(string(enforce))},
+	}}
+
+	if selfType == "spc_t" {
+		findings = append(findings, Finding{
+			Severity:    SeverityCritical,
+			Title:       "process is running as spc_t (super-privileged container), SELinux confinement is effectively bypassed",
+			Evidence:    map[string]string{"type": selfType, "path": "/proc/self/attr/current"},
+			Remediation: "do not label containers spc_t unless they genuinely need full host access",
+		})
+	}
+
+	findings = append(findings, Finding{
+		Severity: SeverityInfo,
+		Title:    "type-enforcement allow-rule diff against host-sensitive types is not implemented",
+		Evidence: map[string]string{"reason": "decoding commons/roles/users/booleans/MLS symbol tables out of /sys/fs/selinux/policy cannot be done safely without a reference policy blob to verify the parser against"},
+	})
+
+	return findings
+}
+
+// selinuxCurrentType extracts the type field ("user:role:type:level") from
+// the process's current SELinux context.
+func selinuxCurrentType() (string, bool) {
+	data, err := os.ReadFile("/proc/self/attr/current")
+	if err != nil {
+		return "", false
+	}
+	ctx := strings.TrimRight(strings.TrimSpace(string(data)), "\x00")
+	fields := strings.Split(ctx, ":")
+	if len(fields) < 3 {
+		return "", false
+	}
+	return fields[2], true
+}
+
+func init() {
+	RegisterSimpleCheck(CategorySecurity, "security.selinux_policy_diff", "Check SELinux enforcement and flag the spc_t super-privileged container type", CheckSELinuxPolicyDiff)
+}
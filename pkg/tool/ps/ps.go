@@ -18,19 +18,35 @@ package ps
 
 import (
 	"fmt"
+
+	"github.com/cdk-team/CDK/pkg/evaluate"
 	"github.com/shirou/gopsutil/v3/process"
-	"log"
 )
 
-func RunPs() {
+// RunPs lists every process visible to CDK as a structured Finding per
+// process, so callers can render it (human table, JSON, SARIF) the same
+// way as the rest of the evaluate checks instead of printing directly.
+func RunPs() []evaluate.Finding {
 	ps, err := process.Processes()
 	if err != nil {
-		log.Fatal("get process list failed.")
+		return []evaluate.Finding{{Severity: evaluate.SeverityInfo, Title: fmt.Sprintf("get process list failed: %v", err)}}
 	}
+
+	findings := make([]evaluate.Finding, 0, len(ps))
 	for _, p := range ps {
 		pexe, _ := p.Exe()
 		ppid, _ := p.Ppid()
 		user, _ := p.Username()
-		fmt.Printf("%v\t%v\t%v\t%v\n", user, p.Pid, ppid, pexe)
+		findings = append(findings, evaluate.Finding{
+			Severity: evaluate.SeverityInfo,
+			Title:    fmt.Sprintf("process %d (%s)", p.Pid, pexe),
+			Evidence: map[string]string{
+				"pid":  fmt.Sprintf("%d", p.Pid),
+				"ppid": fmt.Sprintf("%d", ppid),
+				"user": user,
+				"exe":  pexe,
+			},
+		})
 	}
+	return findings
 }